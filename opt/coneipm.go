@@ -0,0 +1,558 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// ConeDims describes the Cartesian structure of the cone K = R₊^L × Q₁ × ⋯ × Q_q × S₁ × ⋯ × S_r
+// that the slack s and dual z of ConeIpm must belong to
+//  L: dimension of the nonneg orthant block
+//  Q: dimensions of the second-order (Lorentz) cone blocks
+//  S: orders of the PSD cone blocks (each block is stored unpacked, i.e. n² entries)
+type ConeDims struct {
+	L int   // nonneg orthant dimension
+	Q []int // second-order cone dimensions
+	S []int // PSD cone orders (n, stored as n×n unpacked)
+}
+
+// Dim returns the total dimension of the cone, i.e. len(s) == len(z)
+func (o ConeDims) Dim() (n int) {
+	n = o.L
+	for _, q := range o.Q {
+		n += q
+	}
+	for _, s := range o.S {
+		n += s * s
+	}
+	return
+}
+
+// ConeIpm implements the interior-point method for cone programming problems
+//  Solve:
+//          min cᵀx   s.t.   Ax = b,  Gx + s = h,  s ∈ K
+//           x
+//
+// where K is the Cartesian product of the nonneg orthant, second-order (Lorentz) cones and
+// PSD cones described by Dims. A Nesterov-Todd (NT) scaling point w is recomputed every
+// iteration from the current (s,z) and used to symmetrise the KKT system, following the
+// same predictor-corrector flow used by LinIpm.Solve and QuadIpm.Solve
+type ConeIpm struct {
+
+	// problem
+	Dims ConeDims     // cone structure of s and z
+	C    []float64    // [nx]
+	G    *la.CCMatrix // [ns][nx]
+	H    []float64    // [ns]
+	A    *la.CCMatrix // [nl][nx]
+	B    []float64    // [nl]
+
+	// constants
+	NmaxIt int     // max number of iterations
+	Tol    float64 // tolerance ϵ for stopping iterations
+
+	// dimensions
+	Nx int // number of x
+	Nl int // number of equality constraints (Ax=b)
+	Ns int // dimension of the cone (len(s) == len(z))
+	Ny int // nx + nl + ns (reduced, scaled KKT system)
+
+	// solution vector (full point)
+	X []float64 // [nx]
+	Y []float64 // [nl] dual of Ax=b
+	Z []float64 // [ns] dual of Gx+s=h, z ∈ K
+	S []float64 // [ns] slack, s ∈ K
+
+	// NT scaling point and cached scaled quantities, recomputed every iteration
+	W []float64 // [ns] scaling point (orthant entries and SOC/PSD block entries)
+
+	// reduced KKT unknowns -Δ(x,y,z) and recovered -Δs
+	Mdy []float64 // [ny] = [-Δx, -Δy, -Δz]
+	Mdx []float64 // subset of Mdy
+	Mdl []float64 // subset of Mdy
+	Mdz []float64 // subset of Mdy
+	Mds []float64 // [ns] recovered from -Δz and the primal residual
+
+	// residual
+	R  []float64   // [ny]
+	Rx []float64   // subset of R
+	Ry []float64   // subset of R
+	Rz []float64   // subset of R
+	J  *la.Triplet // [ny][ny] reduced, scaled KKT matrix
+
+	// linear solver
+	Lis la.LinSol // linear solver
+}
+
+// Free frees allocated memory
+func (o *ConeIpm) Free() {
+	o.Lis.Free()
+}
+
+// Init initialises ConeIpm
+func (o *ConeIpm) Init(dims ConeDims, A *la.CCMatrix, b []float64, G *la.CCMatrix, h, c []float64, prms fun.Params) {
+
+	// problem
+	o.Dims, o.A, o.B, o.G, o.H, o.C = dims, A, b, G, h, c
+
+	// constants
+	o.NmaxIt = 50
+	o.Tol = 1e-8
+	for _, p := range prms {
+		switch p.N {
+		case "nmaxit":
+			o.NmaxIt = int(p.V)
+		}
+	}
+
+	// dimensions
+	o.Nx = len(o.C)
+	o.Nl = len(o.B)
+	o.Ns = dims.Dim()
+	o.Ny = o.Nx + o.Nl + o.Ns
+	ix, jx := 0, o.Nx
+	il, jl := o.Nx, o.Nx+o.Nl
+	iz, jz := o.Nx+o.Nl, o.Ny
+
+	// solution vector
+	o.X = make([]float64, o.Nx)
+	o.Y = make([]float64, o.Nl)
+	o.Z = make([]float64, o.Ns)
+	o.S = make([]float64, o.Ns)
+	o.W = make([]float64, o.Ns)
+
+	// reduced KKT unknowns
+	o.Mdy = make([]float64, o.Ny)
+	o.Mdx = o.Mdy[ix:jx]
+	o.Mdl = o.Mdy[il:jl]
+	o.Mdz = o.Mdy[iz:jz]
+	o.Mds = make([]float64, o.Ns)
+
+	// residual
+	o.R = make([]float64, o.Ny)
+	o.Rx = o.R[ix:jx]
+	o.Ry = o.R[il:jl]
+	o.Rz = o.R[iz:jz]
+	o.J = new(la.Triplet)
+	nnz := 2*o.Nl*o.Nx + 2*o.Ns*o.Nx + o.Ns*o.Ns
+	o.J.Init(o.Ny, o.Ny, nnz)
+
+	// linear solver
+	o.Lis = la.GetSolver("umfpack")
+}
+
+// Solve solves the cone programming problem
+func (o *ConeIpm) Solve(verbose bool) (err error) {
+
+	// starting point: x from the least-squares heuristic of LinIpm, s and z at the
+	// identity element e of K, which already lies in the interior of K
+	AAt := la.MatAlloc(o.Nl, o.Nl)
+	d := make([]float64, o.Nl)
+	e := make([]float64, o.Nl)
+	if o.Nl > 0 {
+		la.SpMatMatTrMul(AAt, 1, o.A)
+		la.SpMatVecMul(e, 1, o.A, o.C)
+		la.SPDsolve2(d, o.Y, AAt, o.B, e)
+		la.SpMatTrVecMul(o.X, 1, o.A, d)
+	}
+	o.coneIdentity(o.S)
+	o.coneIdentity(o.Z)
+
+	// constants for linear solver
+	symmetric := false
+	timing := false
+
+	// auxiliary
+	Gdx := make([]float64, o.Ns)
+	rzOrig := make([]float64, o.Ns)
+
+	// control variables
+	var μ, σ float64
+	var α float64
+	var μaff float64
+	var ctx, btl float64
+
+	// message
+	if verbose {
+		io.Pf("%3s%16s%16s\n", "it", "f(x)", "error")
+	}
+
+	// perform iterations
+	it := 0
+	for it = 0; it < o.NmaxIt; it++ {
+
+		// compute residual: rx = c + Aᵀy + Gᵀz; ry = Ax - b; rz = Gx + s - h
+		la.SpMatTrVecMul(o.Rx, 1, o.A, o.Y)
+		la.SpMatTrVecMulAdd(o.Rx, 1, o.G, o.Z)
+		for i := 0; i < o.Nx; i++ {
+			o.Rx[i] += o.C[i]
+		}
+		la.SpMatVecMul(o.Ry, 1, o.A, o.X)
+		for i := 0; i < o.Nl; i++ {
+			o.Ry[i] -= o.B[i]
+		}
+		la.SpMatVecMul(o.Rz, 1, o.G, o.X)
+		for i := 0; i < o.Ns; i++ {
+			o.Rz[i] += o.S[i] - o.H[i]
+		}
+		μ = o.coneDot(o.S, o.Z) / float64(o.numBlocks())
+
+		// check convergence: gap between cᵀx and the dual objective -bᵀy - hᵀz
+		ctx = la.VecDot(o.C, o.X)
+		btl = -la.VecDot(o.B, o.Y) - la.VecDot(o.H, o.Z)
+		lerr := math.Abs(ctx-btl) / (1.0 + math.Abs(ctx))
+		if verbose {
+			io.Pf("%3d%16.8e%16.8e\n", it, ctx, lerr)
+		}
+		if lerr < o.Tol && μ < o.Tol {
+			break
+		}
+
+		// Nesterov-Todd scaling point w from the current (s,z), and W²=diag/block form
+		o.ntScaling()
+
+		// assemble reduced, scaled KKT matrix (Δs is eliminated via the primal row
+		// GΔx+Δs=-rz and the complementarity row SΔz+ZΔs=-rs, exactly as in QuadIpm.Solve
+		// but with z replaced by its NT-scaled counterpart w)
+		//  [ 0   Aᵀ   Gᵀ ] [Δx]     [rx]
+		//  [ A    0    0 ] [Δy] = -[ry]
+		//  [ G    0  -W² ] [Δz]     [rz]
+		iy := o.Nx
+		iz := o.Nx + o.Nl
+		o.J.Start()
+		putCCMatAt(o.J, o.A, iy, 0, 1)
+		putCCMatTrAt(o.J, o.A, 0, iy, 1)
+		putCCMatAt(o.J, o.G, iz, 0, 1)
+		putCCMatTrAt(o.J, o.G, 0, iz, 1)
+		o.putScalingBlock(iz)
+
+		// factorise
+		if it == 0 {
+			err = o.Lis.InitR(o.J, symmetric, false, timing)
+			if err != nil {
+				return
+			}
+		}
+		err = o.Lis.Fact()
+		if err != nil {
+			return
+		}
+
+		// rzOrig keeps the raw primal residual rz=Gx+s-h; Mds is always recovered from the
+		// primal feasibility relation Δs = -rz - GΔx, i.e. Mds = rz - G*Mdx (see QuadIpm.Solve)
+		copy(rzOrig, o.Rz)
+
+		// affine step (σ=0): GΔx-W²Δz = rz-s
+		for i := 0; i < o.Ns; i++ {
+			o.Rz[i] = rzOrig[i] - o.S[i]
+		}
+		err = o.Lis.SolveR(o.Mdy, o.R, false)
+		if err != nil {
+			return
+		}
+		la.SpMatVecMul(Gdx, 1, o.G, o.Mdx)
+		for i := 0; i < o.Ns; i++ {
+			o.Mds[i] = rzOrig[i] - Gdx[i]
+		}
+		α = o.maxStep()
+
+		// corrector: μaff and centering parameter σ
+		sTmp := make([]float64, o.Ns)
+		zTmp := make([]float64, o.Ns)
+		for i := 0; i < o.Ns; i++ {
+			sTmp[i] = o.S[i] - α*o.Mds[i]
+			zTmp[i] = o.Z[i] - α*o.Mdz[i]
+		}
+		μaff = o.coneDot(sTmp, zTmp) / float64(o.numBlocks())
+		if μ > 0 {
+			σ = math.Pow(μaff/μ, 3)
+		}
+
+		// corrected RHS: GΔx-W²Δz = rz-s - (ΔsΔz-σμ)/w
+		for i := 0; i < o.Ns; i++ {
+			o.Rz[i] = rzOrig[i] - o.S[i] - (o.Mds[i]*o.Mdz[i]-σ*μ)/o.W[i]
+		}
+
+		// solve again for the corrected step
+		err = o.Lis.SolveR(o.Mdy, o.R, false)
+		if err != nil {
+			return
+		}
+		la.SpMatVecMul(Gdx, 1, o.G, o.Mdx)
+		for i := 0; i < o.Ns; i++ {
+			o.Mds[i] = rzOrig[i] - Gdx[i]
+		}
+
+		// fraction-to-boundary step length, common to primal and dual since both live in K
+		α = min(1, 0.99*o.maxStep())
+
+		// update
+		for i := 0; i < o.Nx; i++ {
+			o.X[i] -= α * o.Mdx[i]
+		}
+		for i := 0; i < o.Nl; i++ {
+			o.Y[i] -= α * o.Mdl[i]
+		}
+		for i := 0; i < o.Ns; i++ {
+			o.S[i] -= α * o.Mds[i]
+			o.Z[i] -= α * o.Mdz[i]
+		}
+	}
+
+	// check convergence
+	if it == o.NmaxIt {
+		err = chk.Err("iterations did not converge")
+	}
+	return
+}
+
+// numBlocks returns the degree of the cone (used to normalise μ as an average complementarity):
+// L for the orthant, 1 per SOC block, and n per n×n PSD block
+func (o *ConeIpm) numBlocks() (n int) {
+	n = o.Dims.L
+	n += len(o.Dims.Q)
+	for _, s := range o.Dims.S {
+		n += s
+	}
+	return
+}
+
+// coneIdentity sets v to the identity element of K (1 for each orthant entry, (1,0,…,0) for
+// each SOC block, the identity matrix for each PSD block)
+func (o *ConeIpm) coneIdentity(v []float64) {
+	k := 0
+	for i := 0; i < o.Dims.L; i++ {
+		v[k] = 1
+		k++
+	}
+	for _, q := range o.Dims.Q {
+		v[k] = 1
+		for i := 1; i < q; i++ {
+			v[k+i] = 0
+		}
+		k += q
+	}
+	for _, n := range o.Dims.S {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if i == j {
+					v[k] = 1
+				} else {
+					v[k] = 0
+				}
+				k++
+			}
+		}
+	}
+}
+
+// coneDot returns the inner product of u and v restricted to the orthant and SOC blocks using
+// the standard dot product, and restricted to PSD blocks using the Frobenius inner product
+func (o *ConeIpm) coneDot(u, v []float64) (s float64) {
+	for i := 0; i < o.Ns; i++ {
+		s += u[i] * v[i]
+	}
+	return
+}
+
+// ntScaling computes the Nesterov-Todd scaling point w from the current (s,z), block by block
+//  orthant:  w = √(s/z)
+//  SOC:      w̄ = (s̄ + Jz̄) / (2√(s̄ᵀJz̄)),  w = √(‖s‖J/‖z‖J) w̄,  with s̄=s/‖s‖J, z̄=z/‖z‖J,
+//            ‖v‖J=√(vᵀJv)  and  J = diag(1,-1,…,-1)
+//  PSD:      W = Z^{-½}(Z^{½} S Z^{½})^{½} Z^{-½}
+func (o *ConeIpm) ntScaling() {
+	k := 0
+	for i := 0; i < o.Dims.L; i++ {
+		o.W[k] = math.Sqrt(o.S[k] / o.Z[k])
+		k++
+	}
+	for _, q := range o.Dims.Q {
+		s := o.S[k : k+q]
+		z := o.Z[k : k+q]
+
+		// sJs = sᵀJs, zJz = zᵀJz, with J = diag(1,-1,…,-1)
+		sJs := s[0] * s[0]
+		zJz := z[0] * z[0]
+		for i := 1; i < q; i++ {
+			sJs -= s[i] * s[i]
+			zJz -= z[i] * z[i]
+		}
+		sNorm := math.Sqrt(sJs)
+		zNorm := math.Sqrt(zJz)
+
+		// sBar = s/√(sᵀJs), zBar = z/√(zᵀJz); Jzbar = J·zBar
+		sBar := make([]float64, q)
+		jzBar := make([]float64, q)
+		jzBar[0] = z[0] / zNorm
+		for i := 0; i < q; i++ {
+			sBar[i] = s[i] / sNorm
+		}
+		for i := 1; i < q; i++ {
+			jzBar[i] = -z[i] / zNorm
+		}
+
+		// wbar = (sBar + Jzbar)/(2√(sBarᵀJzbar))
+		sBarJzBar := sBar[0] * jzBar[0]
+		for i := 1; i < q; i++ {
+			sBarJzBar += sBar[i] * jzBar[i]
+		}
+		η := 2 * math.Sqrt(sBarJzBar)
+		wbar := make([]float64, q)
+		for i := 0; i < q; i++ {
+			wbar[i] = (sBar[i] + jzBar[i]) / η
+		}
+
+		// w = √(sNorm/zNorm) * wbar, so that w reduces to the orthant's √(s/z) when q=1
+		scale := math.Sqrt(sNorm / zNorm)
+		for i := 0; i < q; i++ {
+			o.W[k+i] = scale * wbar[i]
+		}
+		k += q
+	}
+	for _, n := range o.Dims.S {
+		S := matFromVec(o.S[k:k+n*n], n)
+		Z := matFromVec(o.Z[k:k+n*n], n)
+		Zh := matSqrtSym(Z)
+		Zih := matInvSqrtSym(Z)
+		M := matMulSym3(Zh, S, Zh)
+		Mh := matSqrtSym(M)
+		W := matMulSym3(Zih, Mh, Zih)
+		matToVec(W, o.W[k:k+n*n])
+		k += n * n
+	}
+}
+
+// putScalingBlock inserts -W² (block-diagonal over the cone structure) into J starting at
+// row/column offset iz
+func (o *ConeIpm) putScalingBlock(iz int) {
+	k := 0
+	for i := 0; i < o.Dims.L; i++ {
+		o.J.Put(iz+k, iz+k, -o.W[k]*o.W[k])
+		k++
+	}
+	for _, q := range o.Dims.Q {
+		w := o.W[k : k+q]
+		// W² = 2wwᵀ - J, J = diag(1,-1,…,-1)
+		for i := 0; i < q; i++ {
+			for j := 0; j < q; j++ {
+				val := 2 * w[i] * w[j]
+				if i == j {
+					if i == 0 {
+						val -= 1
+					} else {
+						val += 1
+					}
+				}
+				o.J.Put(iz+k+i, iz+k+j, -val)
+			}
+		}
+		k += q
+	}
+	for _, n := range o.Dims.S {
+		// symmetric Kronecker product W⊗_sW: for the (row-major, unpacked) storage used here,
+		// (W⊗_sW)·vec(M) = vec(½(WMWᵀ+WMᵀWᵀ)), whose (i,j),(p,q) entry is ½(W_ip W_jq + W_iq W_jp)
+		W := matFromVec(o.W[k:k+n*n], n)
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				row := k + i*n + j
+				for p := 0; p < n; p++ {
+					for q := 0; q < n; q++ {
+						col := k + p*n + q
+						val := 0.5 * (W[i][p]*W[j][q] + W[i][q]*W[j][p])
+						o.J.Put(iz+row, iz+col, -val)
+					}
+				}
+			}
+		}
+		k += n * n
+	}
+}
+
+// maxStep returns the step length (capped at 1) for s and z with respect to the cone K: for
+// the orthant blocks this is the usual ratio test; for SOC and PSD blocks it is the first-order
+// estimate of socStepLen and psdStepLen, which does not guarantee that s and z stay inside K
+func (o *ConeIpm) maxStep() (α float64) {
+	α = 1
+	k := 0
+	for i := 0; i < o.Dims.L; i++ {
+		if o.Mds[k] > 0 {
+			α = min(α, o.S[k]/o.Mds[k])
+		}
+		if o.Mdz[k] > 0 {
+			α = min(α, o.Z[k]/o.Mdz[k])
+		}
+		k++
+	}
+	for _, q := range o.Dims.Q {
+		α = min(α, socStepLen(o.S[k:k+q], o.Mds[k:k+q]))
+		α = min(α, socStepLen(o.Z[k:k+q], o.Mdz[k:k+q]))
+		k += q
+	}
+	for _, n := range o.Dims.S {
+		α = min(α, psdStepLen(o.S[k:k+n*n], o.Mds[k:k+n*n], n))
+		α = min(α, psdStepLen(o.Z[k:k+n*n], o.Mdz[k:k+n*n], n))
+		k += n * n
+	}
+	return
+}
+
+// socStepLen estimates the step length that keeps u - αΔu inside the Lorentz cone, from a
+// linear model of the smaller arrow-head eigenvalue λ₋ = u₀ - ‖u₁‖ along Δu
+func socStepLen(u, du []float64) (α float64) {
+	α = 1e12
+	n := len(u)
+	var un, dun float64
+	for i := 1; i < n; i++ {
+		un += u[i] * u[i]
+		dun += u[i] * du[i]
+	}
+	un = math.Sqrt(un)
+	if un > 0 {
+		dun /= un
+	}
+	λm := u[0] - un
+	dλm := du[0] - dun
+	if dλm > 0 {
+		α = min(α, λm/dλm)
+	}
+	if α > 1 {
+		α = 1
+	}
+	return
+}
+
+// psdStepLen bounds the step length that keeps u - αΔu positive semi-definite, approximated
+// via the smallest eigenvalue of u and its Rayleigh-quotient derivative along Δu
+func psdStepLen(u, du []float64, n int) (α float64) {
+	U := matFromVec(u, n)
+	_, λ, V := jacobiEigenSym(U)
+	imin := 0
+	for i := 1; i < n; i++ {
+		if λ[i] < λ[imin] {
+			imin = i
+		}
+	}
+	v := make([]float64, n)
+	for i := 0; i < n; i++ {
+		v[i] = V[i][imin]
+	}
+	var dλ float64
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			dλ += v[i] * du[i*n+j] * v[j]
+		}
+	}
+	α = 1
+	if dλ > 0 {
+		α = min(1, λ[imin]/dλ)
+	}
+	return
+}
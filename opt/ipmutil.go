@@ -0,0 +1,27 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import "github.com/cpmech/gosl/la"
+
+// putCCMatAt adds the entries of the sparse matrix m, scaled by α, into the triplet t
+// starting at row/column offsets iOff and jOff
+func putCCMatAt(t *la.Triplet, m *la.CCMatrix, iOff, jOff int, α float64) {
+	for j := 0; j < m.N; j++ {
+		for p := m.Ap[j]; p < m.Ap[j+1]; p++ {
+			t.Put(iOff+m.Ai[p], jOff+j, α*m.Ax[p])
+		}
+	}
+}
+
+// putCCMatTrAt adds the entries of the transpose of the sparse matrix m, scaled by α, into
+// the triplet t starting at row/column offsets iOff and jOff
+func putCCMatTrAt(t *la.Triplet, m *la.CCMatrix, iOff, jOff int, α float64) {
+	for j := 0; j < m.N; j++ {
+		for p := m.Ap[j]; p < m.Ap[j+1]; p++ {
+			t.Put(iOff+j, jOff+m.Ai[p], α*m.Ax[p])
+		}
+	}
+}
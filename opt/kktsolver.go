@@ -0,0 +1,380 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// KKTSolver solves the linear system J·x = b that has to be assembled and refactorised at
+// every IPM iteration. Implementations may factorise J directly (e.g. UMFPACK) or solve it
+// iteratively with a Krylov method, reusing the matrix-vector product with J (and, for some
+// methods, Jᵀ) instead of a full factorisation
+type KKTSolver interface {
+
+	// Init gives the solver its first look at the (possibly unsymmetric) Jacobian J
+	Init(J *la.Triplet, symmetric bool) (err error)
+
+	// Fact (re)computes whatever the solver needs from the current values in J; for direct
+	// solvers this is a numeric factorisation, for iterative solvers this refreshes the
+	// cached matrix-vector product data and the preconditioner
+	Fact() (err error)
+
+	// Solve computes x such that J·x = b. If warmStart is true and the solver is iterative,
+	// x is used, on entry, as the initial guess (e.g. the -Δy of the previous IPM iteration)
+	Solve(x, b []float64, warmStart bool) (err error)
+
+	// Free releases memory held by the solver
+	Free()
+}
+
+// NewKKTSolver allocates a KKTSolver selected by the value of prms["kktsolver"]: 0 or absent
+// selects "umfpack", the default direct, full-refactorisation behaviour of LinIpm.Solve;
+// 1 selects "bicgstab" and 2 selects "gmres", the iterative backends, configurable via the
+// "restart", "tol" and "maxit" parameters
+func NewKKTSolver(prms fun.Params) (kks KKTSolver) {
+	name := "umfpack"
+	restart := 20
+	tol := 1e-10
+	maxit := 200
+	for _, p := range prms {
+		switch p.N {
+		case "kktsolver":
+			switch int(p.V) {
+			case 1:
+				name = "bicgstab"
+			case 2:
+				name = "gmres"
+			}
+		case "restart":
+			restart = int(p.V)
+		case "tol":
+			tol = p.V
+		case "maxit":
+			maxit = int(p.V)
+		}
+	}
+	switch name {
+	case "bicgstab":
+		return &bicgstabSolver{tol: tol, maxit: maxit, prec: new(jacobiPrecond)}
+	case "gmres":
+		return &gmresSolver{restart: restart, tol: tol, maxit: maxit, prec: new(jacobiPrecond)}
+	default:
+		return &directKKT{}
+	}
+}
+
+// directKKT is the original KKTSolver: a full UMFPACK factorisation at every iteration
+type directKKT struct {
+	lis la.LinSol
+}
+
+func (o *directKKT) Init(J *la.Triplet, symmetric bool) (err error) {
+	o.lis = la.GetSolver("umfpack")
+	return o.lis.InitR(J, symmetric, false, false)
+}
+
+func (o *directKKT) Fact() (err error) {
+	return o.lis.Fact()
+}
+
+func (o *directKKT) Solve(x, b []float64, warmStart bool) (err error) {
+	return o.lis.SolveR(x, b, false)
+}
+
+func (o *directKKT) Free() {
+	o.lis.Free()
+}
+
+// kktPrecond applies an approximate inverse of J to speed up Krylov convergence
+type kktPrecond interface {
+
+	// Update refreshes the preconditioner from the current J
+	Update(J *la.CCMatrix)
+
+	// Apply sets z ≈ J⁻¹ r
+	Apply(z, r []float64)
+}
+
+// jacobiPrecond is the simplest preconditioner: the inverse of diag(J), with 1 used where a
+// diagonal entry of J is zero
+type jacobiPrecond struct {
+	invD []float64
+}
+
+func (o *jacobiPrecond) Update(J *la.CCMatrix) {
+	n := J.N
+	if len(o.invD) != n {
+		o.invD = make([]float64, n)
+	}
+	for i := range o.invD {
+		o.invD[i] = 0
+	}
+	for j := 0; j < n; j++ {
+		for p := J.Ap[j]; p < J.Ap[j+1]; p++ {
+			if J.Ai[p] == j {
+				o.invD[j] = J.Ax[p]
+			}
+		}
+	}
+	for i, d := range o.invD {
+		if d != 0 {
+			o.invD[i] = 1 / d
+		} else {
+			o.invD[i] = 1
+		}
+	}
+}
+
+func (o *jacobiPrecond) Apply(z, r []float64) {
+	for i := range z {
+		z[i] = o.invD[i] * r[i]
+	}
+}
+
+// bicgstabSolver solves J·x=b with the right-preconditioned BiCGStab method, using only
+// matrix-vector products with J (no Jᵀ)
+type bicgstabSolver struct {
+	tri   *la.Triplet
+	mat   *la.CCMatrix
+	tol   float64
+	maxit int
+	prec  kktPrecond
+}
+
+func (o *bicgstabSolver) Init(J *la.Triplet, symmetric bool) (err error) {
+	o.tri = J
+	o.mat = J.ToMatrix()
+	return nil
+}
+
+func (o *bicgstabSolver) Fact() (err error) {
+	// J is reassembled in place every IPM iteration; rebuild the compressed-column matrix from
+	// the live triplet before refreshing the preconditioner, exactly as directKKT.Fact()
+	// re-factorises the live triplet rather than one frozen at Init
+	o.mat = o.tri.ToMatrix()
+	o.prec.Update(o.mat)
+	return nil
+}
+
+func (o *bicgstabSolver) Solve(x, b []float64, warmStart bool) (err error) {
+	n := len(b)
+	if !warmStart {
+		for i := range x {
+			x[i] = 0
+		}
+	}
+	r := make([]float64, n)
+	matVec(r, o.mat, x)
+	for i := range r {
+		r[i] = b[i] - r[i]
+	}
+	rhat := make([]float64, n)
+	copy(rhat, r)
+	ρ, α, ω := 1.0, 1.0, 1.0
+	v := make([]float64, n)
+	p := make([]float64, n)
+	y := make([]float64, n)
+	s := make([]float64, n)
+	z := make([]float64, n)
+	t := make([]float64, n)
+	bnorm := la.VecDot(b, b)
+	if bnorm == 0 {
+		bnorm = 1
+	}
+	bnorm = math.Sqrt(bnorm)
+	for it := 0; it < o.maxit; it++ {
+		ρnew := la.VecDot(rhat, r)
+		if ρnew == 0 {
+			break
+		}
+		β := (ρnew / ρ) * (α / ω)
+		for i := 0; i < n; i++ {
+			p[i] = r[i] + β*(p[i]-ω*v[i])
+		}
+		o.prec.Apply(y, p)
+		matVec(v, o.mat, y)
+		α = ρnew / la.VecDot(rhat, v)
+		for i := 0; i < n; i++ {
+			s[i] = r[i] - α*v[i]
+		}
+		if math.Sqrt(la.VecDot(s, s))/bnorm < o.tol {
+			for i := 0; i < n; i++ {
+				x[i] += α * y[i]
+			}
+			return nil
+		}
+		o.prec.Apply(z, s)
+		matVec(t, o.mat, z)
+		ω = la.VecDot(t, s) / la.VecDot(t, t)
+		for i := 0; i < n; i++ {
+			x[i] += α*y[i] + ω*z[i]
+			r[i] = s[i] - ω*t[i]
+		}
+		if math.Sqrt(la.VecDot(r, r))/bnorm < o.tol {
+			return nil
+		}
+		ρ = ρnew
+		if ω == 0 {
+			break
+		}
+	}
+	return chk.Err("bicgstab: did not converge")
+}
+
+func (o *bicgstabSolver) Free() {}
+
+// gmresSolver solves J·x=b with restarted GMRES(k), using only matrix-vector products with J
+type gmresSolver struct {
+	tri     *la.Triplet
+	mat     *la.CCMatrix
+	restart int
+	tol     float64
+	maxit   int
+	prec    kktPrecond
+}
+
+func (o *gmresSolver) Init(J *la.Triplet, symmetric bool) (err error) {
+	o.tri = J
+	o.mat = J.ToMatrix()
+	return nil
+}
+
+func (o *gmresSolver) Fact() (err error) {
+	// see bicgstabSolver.Fact: J is reassembled in place every iteration, so rebuild o.mat
+	// from the live triplet rather than reusing the one captured at Init
+	o.mat = o.tri.ToMatrix()
+	o.prec.Update(o.mat)
+	return nil
+}
+
+func (o *gmresSolver) Solve(x, b []float64, warmStart bool) (err error) {
+	n := len(b)
+	if !warmStart {
+		for i := range x {
+			x[i] = 0
+		}
+	}
+	k := o.restart
+	if k > n {
+		k = n
+	}
+	bnorm := math.Sqrt(la.VecDot(b, b))
+	if bnorm == 0 {
+		bnorm = 1
+	}
+	for cycle := 0; cycle < o.maxit; cycle++ {
+		r := make([]float64, n)
+		matVec(r, o.mat, x)
+		for i := range r {
+			r[i] = b[i] - r[i]
+		}
+		β := math.Sqrt(la.VecDot(r, r))
+		if β/bnorm < o.tol {
+			return nil
+		}
+		V := make([][]float64, k+1)
+		H := la.MatAlloc(k+1, k)
+		V[0] = make([]float64, n)
+		for i := 0; i < n; i++ {
+			V[0][i] = r[i] / β
+		}
+		g := make([]float64, k+1)
+		g[0] = β
+		var m int
+		for m = 0; m < k; m++ {
+			w := make([]float64, n)
+			z := make([]float64, n)
+			o.prec.Apply(z, V[m])
+			matVec(w, o.mat, z)
+			for i := 0; i <= m; i++ {
+				H[i][m] = la.VecDot(w, V[i])
+				for j := 0; j < n; j++ {
+					w[j] -= H[i][m] * V[i][j]
+				}
+			}
+			H[m+1][m] = math.Sqrt(la.VecDot(w, w))
+			V[m+1] = make([]float64, n)
+			if H[m+1][m] > 1e-300 {
+				for i := 0; i < n; i++ {
+					V[m+1][i] = w[i] / H[m+1][m]
+				}
+			}
+			if H[m+1][m]/bnorm < o.tol {
+				m++
+				break
+			}
+		}
+		if m > k {
+			m = k
+		}
+		y := solveLeastSquaresHessenberg(H, g, m)
+		dx := make([]float64, n)
+		for j := 0; j < m; j++ {
+			z := make([]float64, n)
+			o.prec.Apply(z, V[j])
+			for i := 0; i < n; i++ {
+				dx[i] += y[j] * z[i]
+			}
+		}
+		for i := 0; i < n; i++ {
+			x[i] += dx[i]
+		}
+	}
+	return chk.Err("gmres: did not converge")
+}
+
+func (o *gmresSolver) Free() {}
+
+// solveLeastSquaresHessenberg solves the small (m+1)×m least-squares problem that arises from
+// the Arnoldi process, via Givens rotations
+func solveLeastSquaresHessenberg(H [][]float64, g []float64, m int) (y []float64) {
+	gg := make([]float64, m+1)
+	copy(gg, g[:m+1])
+	R := make([][]float64, m+1)
+	for i := range R {
+		R[i] = make([]float64, m)
+		copy(R[i], H[i][:m])
+	}
+	for i := 0; i < m; i++ {
+		denom := math.Hypot(R[i][i], R[i+1][i])
+		if denom == 0 {
+			continue
+		}
+		c := R[i][i] / denom
+		s := R[i+1][i] / denom
+		for j := i; j < m; j++ {
+			rij, ri1j := R[i][j], R[i+1][j]
+			R[i][j] = c*rij + s*ri1j
+			R[i+1][j] = -s*rij + c*ri1j
+		}
+		gi, gi1 := gg[i], gg[i+1]
+		gg[i] = c*gi + s*gi1
+		gg[i+1] = -s*gi + c*gi1
+	}
+	y = make([]float64, m)
+	for i := m - 1; i >= 0; i-- {
+		s := gg[i]
+		for j := i + 1; j < m; j++ {
+			s -= R[i][j] * y[j]
+		}
+		if R[i][i] != 0 {
+			y[i] = s / R[i][i]
+		}
+	}
+	return
+}
+
+// matVec computes v := M*u for a compressed-column matrix M
+func matVec(v []float64, M *la.CCMatrix, u []float64) {
+	for i := range v {
+		v[i] = 0
+	}
+	la.SpMatVecMul(v, 1, M, u)
+}
@@ -57,12 +57,15 @@ type LinIpm struct {
 	J  *la.Triplet // [ny][ny] Jacobian matrix
 
 	// linear solver
-	Lis la.LinSol // linear solver
+	Kks KKTSolver // KKT solver; defaults to a direct UMFPACK factorisation (see NewKKTSolver)
+
+	// warm-start
+	warmStarted bool // if true, Solve skips the self-starting (cold-start) heuristic
 }
 
 // Free frees allocated memory
 func (o *LinIpm) Free() {
-	o.Lis.Free()
+	o.Kks.Free()
 }
 
 // Init initialises LinIpm
@@ -74,6 +77,7 @@ func (o *LinIpm) Init(A *la.CCMatrix, b, c []float64, prms fun.Params) {
 	// constants
 	o.NmaxIt = 50
 	o.Tol = 1e-8
+	o.warmStarted = false // Init (re)allocates X,L,S; call WarmStart explicitly if needed
 	for _, p := range prms {
 		switch p.N {
 		case "nmaxit":
@@ -108,49 +112,54 @@ func (o *LinIpm) Init(A *la.CCMatrix, b, c []float64, prms fun.Params) {
 	nnz := 2*o.Nl*o.Nx + 3*o.Nx
 	o.J.Init(o.Ny, o.Ny, nnz)
 
-	// linear solver
-	o.Lis = la.GetSolver("umfpack")
+	// linear solver: defaults to direct UMFPACK, but an iterative backend (BiCGStab, GMRES)
+	// can be selected via the "kktsolver" parameter; see NewKKTSolver
+	o.Kks = NewKKTSolver(prms)
 }
 
 // Solve solves linear programming problem
 func (o *LinIpm) Solve(verbose bool) (err error) {
 
-	// starting point
-	AAt := la.MatAlloc(o.Nl, o.Nl)         // A*Aᵀ
-	d := make([]float64, o.Nl)             // inv(AAt) * b
-	e := make([]float64, o.Nl)             // A * c
-	la.SpMatMatTrMul(AAt, 1, o.A)          // AAt := A*Aᵀ
-	la.SpMatVecMul(e, 1, o.A, o.C)         // e := A * c
-	la.SPDsolve2(d, o.L, AAt, o.B, e)      // d := inv(AAt) * b  and  L := inv(AAt) * e
-	la.SpMatTrVecMul(o.X, 1, o.A, d)       // x := Aᵀ * d
-	la.VecCopy(o.S, 1, o.C)                // s := c
-	la.SpMatTrVecMulAdd(o.S, -1, o.A, o.L) // s -= Aᵀλ
-	xmin := o.X[0]
-	smin := o.S[0]
-	for i := 1; i < o.Nx; i++ {
-		xmin = min(xmin, o.X[i])
-		smin = min(smin, o.S[i])
-	}
-	δx := max(-1.5*xmin, 0)
-	δs := max(-1.5*smin, 0)
-	var xdots, xsum, ssum float64
-	for i := 0; i < o.Nx; i++ {
-		o.X[i] += δx
-		o.S[i] += δs
-		xdots += o.X[i] * o.S[i]
-		xsum += o.X[i]
-		ssum += o.S[i]
-	}
-	δx = 0.5 * xdots / ssum
-	δs = 0.5 * xdots / xsum
-	for i := 0; i < o.Nx; i++ {
-		o.X[i] += δx
-		o.S[i] += δs
+	// starting point (skipped when WarmStart has supplied a feasible-ish point already,
+	// e.g. the optimum of a closely-related LP in a sequence of parametric solves, and on
+	// every later call to Solve, which then starts from the last iterate)
+	if !o.warmStarted {
+		AAt := la.MatAlloc(o.Nl, o.Nl)         // A*Aᵀ
+		d := make([]float64, o.Nl)             // inv(AAt) * b
+		e := make([]float64, o.Nl)             // A * c
+		la.SpMatMatTrMul(AAt, 1, o.A)          // AAt := A*Aᵀ
+		la.SpMatVecMul(e, 1, o.A, o.C)         // e := A * c
+		la.SPDsolve2(d, o.L, AAt, o.B, e)      // d := inv(AAt) * b  and  L := inv(AAt) * e
+		la.SpMatTrVecMul(o.X, 1, o.A, d)       // x := Aᵀ * d
+		la.VecCopy(o.S, 1, o.C)                // s := c
+		la.SpMatTrVecMulAdd(o.S, -1, o.A, o.L) // s -= Aᵀλ
+		xmin := o.X[0]
+		smin := o.S[0]
+		for i := 1; i < o.Nx; i++ {
+			xmin = min(xmin, o.X[i])
+			smin = min(smin, o.S[i])
+		}
+		δx := max(-1.5*xmin, 0)
+		δs := max(-1.5*smin, 0)
+		var xdots, xsum, ssum float64
+		for i := 0; i < o.Nx; i++ {
+			o.X[i] += δx
+			o.S[i] += δs
+			xdots += o.X[i] * o.S[i]
+			xsum += o.X[i]
+			ssum += o.S[i]
+		}
+		δx = 0.5 * xdots / ssum
+		δs = 0.5 * xdots / xsum
+		for i := 0; i < o.Nx; i++ {
+			o.X[i] += δx
+			o.S[i] += δs
+		}
 	}
+	o.warmStarted = true
 
 	// constants for linear solver
 	symmetric := false
-	timing := false
 
 	// auxiliary
 	I := o.Nx + o.Nl
@@ -189,17 +198,9 @@ func (o *LinIpm) Solve(verbose bool) (err error) {
 		}
 		μ /= float64(o.Nx)
 
-		// check convergence
-		lerr := math.Abs(ctx-btl) / (1.0 + math.Abs(ctx))
-		if verbose {
-			fx := la.VecDot(o.C, o.X)
-			io.Pf("%3d%16.8e%16.8e\n", it, fx, lerr)
-		}
-		if lerr < o.Tol {
-			break
-		}
-
-		// assemble Jacobian
+		// assemble Jacobian: always rebuilt and re-factorised from the current (X,L,S)
+		// before the convergence check below, so that Kks holds the factorisation at the
+		// converged point on return (SensitivityRHS/SensitivityCost rely on this)
 		o.J.Start()
 		o.J.PutCCMatAndMatT(o.A)
 		for i := 0; i < o.Nx; i++ {
@@ -207,19 +208,29 @@ func (o *LinIpm) Solve(verbose bool) (err error) {
 			o.J.Put(I+i, i, o.S[i])
 			o.J.Put(I+i, I+i, o.X[i])
 		}
-
-		// solve linear system
 		if it == 0 {
-			err = o.Lis.InitR(o.J, symmetric, false, timing)
+			err = o.Kks.Init(o.J, symmetric)
 			if err != nil {
 				return
 			}
 		}
-		err = o.Lis.Fact()
+		err = o.Kks.Fact()
 		if err != nil {
 			return
 		}
-		err = o.Lis.SolveR(o.Mdy, o.R, false) // mdy := inv(J) * R
+
+		// check convergence
+		lerr := math.Abs(ctx-btl) / (1.0 + math.Abs(ctx))
+		if verbose {
+			fx := la.VecDot(o.C, o.X)
+			io.Pf("%3d%16.8e%16.8e\n", it, fx, lerr)
+		}
+		if lerr < o.Tol {
+			break
+		}
+
+		// solve linear system
+		err = o.Kks.Solve(o.Mdy, o.R, it > 0) // mdy := inv(J) * R; warm-start from the previous iteration
 		if err != nil {
 			return
 		}
@@ -241,7 +252,7 @@ func (o *LinIpm) Solve(verbose bool) (err error) {
 		}
 
 		// solve linear system again
-		err = o.Lis.SolveR(o.Mdy, o.R, false) // mdy := inv(J) * R
+		err = o.Kks.Solve(o.Mdy, o.R, true) // mdy := inv(J) * R; warm-start from the affine step
 		if err != nil {
 			return
 		}
@@ -290,3 +301,50 @@ func (o *LinIpm) calc_min_ratios() (xrmin, srmin float64) {
 	}
 	return
 }
+
+// WarmStart sets (x,λ,s) to a previously-known solution (e.g. the optimum of a closely-related
+// LP) so that the next call to Solve skips the self-starting heuristic. This is essential for
+// sequences of parametric LPs (rolling MPC, portfolio rebalancing, branch-and-bound cutting
+// planes) where thousands of related problems are solved and cold-starting every one of them
+// would dominate the runtime
+func (o *LinIpm) WarmStart(xPrev, lPrev, sPrev []float64) {
+	copy(o.X, xPrev)
+	copy(o.L, lPrev)
+	copy(o.S, sPrev)
+	o.warmStarted = true
+}
+
+// SensitivityRHS computes the derivative of the optimal (x,λ) with respect to a perturbation
+// dB of the right-hand-side b, reusing the last KKT factorisation computed by Solve. This is
+// the LP analogue of the implicit-function-theorem sensitivity dx = -J⁻¹ ∂R/∂p: here only the
+// λ-residual depends on b (Rλ = Ax-b), so the KKT system is solved with (0,dB,0) on the RHS
+func (o *LinIpm) SensitivityRHS(dB []float64) (dx, dλ []float64) {
+	il, jl := o.Nx, o.Nx+o.Nl
+	rhs := make([]float64, o.Ny)
+	copy(rhs[il:jl], dB)
+	dy := make([]float64, o.Ny)
+	err := o.Kks.Solve(dy, rhs, false)
+	if err != nil {
+		chk.Panic("SensitivityRHS failed: %v", err)
+	}
+	dx = dy[0:o.Nx]
+	dλ = dy[il:jl]
+	return
+}
+
+// SensitivityCost computes the derivative of the optimal (x,λ) with respect to a perturbation
+// dC of the cost vector c, reusing the last KKT factorisation computed by Solve. Only the
+// x-residual depends on c (Rx = Aᵀλ+s-c), so the KKT system is solved with (dC,0,0) on the RHS
+func (o *LinIpm) SensitivityCost(dC []float64) (dx, dλ []float64) {
+	rhs := make([]float64, o.Ny)
+	copy(rhs[0:o.Nx], dC)
+	dy := make([]float64, o.Ny)
+	err := o.Kks.Solve(dy, rhs, false)
+	if err != nil {
+		chk.Panic("SensitivityCost failed: %v", err)
+	}
+	il, jl := o.Nx, o.Nx+o.Nl
+	dx = dy[0:o.Nx]
+	dλ = dy[il:jl]
+	return
+}
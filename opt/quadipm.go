@@ -0,0 +1,341 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// QuadIpm implements the interior-point method for convex quadratic programming problems
+//  Solve:
+//          min ½xᵀPx + qᵀx   s.t.   Gx + s = h,  Ax = b,  s ≥ 0
+//           x
+//
+// P must be symmetric positive semi-definite. The nonneg-orthant inequality Gx+s=h, s≥0 is
+// handled with the same Mehrotra predictor-corrector scheme used by LinIpm.Solve
+type QuadIpm struct {
+
+	// problem
+	Ptri *la.Triplet  // [nx][nx] objective Hessian, as given by the user
+	P    *la.CCMatrix // [nx][nx] objective Hessian, compressed-column
+	Q    []float64    // [nx]
+	G    *la.CCMatrix // [ns][nx]
+	H    []float64    // [ns]
+	A    *la.CCMatrix // [nl][nx]
+	B    []float64    // [nl]
+
+	// constants
+	NmaxIt int     // max number of iterations
+	Tol    float64 // tolerance ϵ for stopping iterations
+
+	// dimensions
+	Nx int // number of x
+	Nl int // number of equality constraints (Ax=b)
+	Ns int // number of inequality constraints (Gx+s=h)
+	Ny int // number of y = nx + nl + ns (reduced KKT system)
+
+	// solution vector (full point)
+	X  []float64 // [nx]
+	Ye []float64 // [nl] dual of Ax=b
+	Z  []float64 // [ns] dual of Gx+s=h
+	S  []float64 // [ns] slack, s ≥ 0
+
+	// reduced KKT unknowns -Δ(x,ye,z) and recovered -Δs
+	Mdy  []float64 // [ny] = [-Δx, -Δye, -Δz]
+	Mdx  []float64 // subset of Mdy
+	Mdye []float64 // subset of Mdy
+	Mdz  []float64 // subset of Mdy
+	Mds  []float64 // [ns] recovered from -Δz and primal residual
+
+	// residual
+	R   []float64   // [ny]
+	Rx  []float64   // subset of R
+	Rye []float64   // subset of R
+	Rz  []float64   // subset of R
+	J   *la.Triplet // [ny][ny] reduced KKT (Jacobian) matrix
+
+	// linear solver
+	Lis la.LinSol // linear solver
+}
+
+// Free frees allocated memory
+func (o *QuadIpm) Free() {
+	o.Lis.Free()
+}
+
+// Init initialises QuadIpm
+func (o *QuadIpm) Init(P *la.Triplet, q []float64, G *la.CCMatrix, h []float64, A *la.CCMatrix, b []float64, prms fun.Params) {
+
+	// problem
+	o.Ptri, o.Q, o.G, o.H, o.A, o.B = P, q, G, h, A, b
+	o.P = P.ToMatrix()
+
+	// constants
+	o.NmaxIt = 50
+	o.Tol = 1e-8
+	for _, p := range prms {
+		switch p.N {
+		case "nmaxit":
+			o.NmaxIt = int(p.V)
+		}
+	}
+
+	// dimensions
+	o.Nx = len(o.Q)
+	o.Nl = len(o.B)
+	o.Ns = len(o.H)
+	o.Ny = o.Nx + o.Nl + o.Ns
+	ix, jx := 0, o.Nx
+	iy, jy := o.Nx, o.Nx+o.Nl
+	iz, jz := o.Nx+o.Nl, o.Ny
+
+	// solution vector
+	o.X = make([]float64, o.Nx)
+	o.Ye = make([]float64, o.Nl)
+	o.Z = make([]float64, o.Ns)
+	o.S = make([]float64, o.Ns)
+
+	// reduced KKT unknowns
+	o.Mdy = make([]float64, o.Ny)
+	o.Mdx = o.Mdy[ix:jx]
+	o.Mdye = o.Mdy[iy:jy]
+	o.Mdz = o.Mdy[iz:jz]
+	o.Mds = make([]float64, o.Ns)
+
+	// residual
+	o.R = make([]float64, o.Ny)
+	o.Rx = o.R[ix:jx]
+	o.Rye = o.R[iy:jy]
+	o.Rz = o.R[iz:jz]
+	o.J = new(la.Triplet)
+	nnz := o.Nx*o.Nx + 2*o.Nl*o.Nx + 2*o.Ns*o.Nx + o.Ns
+	o.J.Init(o.Ny, o.Ny, nnz)
+
+	// linear solver
+	o.Lis = la.GetSolver("umfpack")
+}
+
+// Solve solves the quadratic programming problem
+func (o *QuadIpm) Solve(verbose bool) (err error) {
+
+	// starting point: ignore P and the inequality structure, use the same least-squares
+	// heuristic of LinIpm to get a feasible-ish x, then shift (s,z) into the strictly
+	// positive orthant
+	AAt := la.MatAlloc(o.Nl, o.Nl)
+	d := make([]float64, o.Nl)
+	e := make([]float64, o.Nl)
+	if o.Nl > 0 {
+		la.SpMatMatTrMul(AAt, 1, o.A)
+		la.SpMatVecMul(e, 1, o.A, o.Q)
+		la.SPDsolve2(d, o.Ye, AAt, o.B, e)
+		la.SpMatTrVecMul(o.X, 1, o.A, d)
+	}
+	gx := make([]float64, o.Ns)
+	la.SpMatVecMul(gx, 1, o.G, o.X)
+	for i := 0; i < o.Ns; i++ {
+		o.S[i] = o.H[i] - gx[i]
+		o.Z[i] = 1.0
+	}
+	smin := o.S[0]
+	for i := 1; i < o.Ns; i++ {
+		smin = min(smin, o.S[i])
+	}
+	δs := max(-1.5*smin, 0)
+	if smin == 0 {
+		δs = 1 // s touches the boundary: move it strictly inside (avoids 0/0 below)
+	}
+	var sdotz, ssum, zsum float64
+	for i := 0; i < o.Ns; i++ {
+		o.S[i] += δs
+		sdotz += o.S[i] * o.Z[i]
+		ssum += o.S[i]
+		zsum += o.Z[i]
+	}
+	δs = 0.5 * sdotz / zsum
+	δz := 0.5 * sdotz / ssum
+	for i := 0; i < o.Ns; i++ {
+		o.S[i] += δs
+		o.Z[i] += δz
+	}
+
+	// constants for linear solver
+	symmetric := false
+	timing := false
+
+	// auxiliary
+	Px := make([]float64, o.Nx)
+	Gdx := make([]float64, o.Ns)
+	rzOrig := make([]float64, o.Ns)
+
+	// control variables
+	var μ, σ float64
+	var αp, αd float64
+	var μaff float64
+	var ctx, btl float64
+
+	// message
+	if verbose {
+		io.Pf("%3s%16s%16s\n", "it", "f(x)", "error")
+	}
+
+	// perform iterations
+	it := 0
+	for it = 0; it < o.NmaxIt; it++ {
+
+		// compute residual: rx = Px + q + Aᵀye + Gᵀz; rye = Ax - b; rz = Gx + s - h
+		la.SpMatVecMul(Px, 1, o.P, o.X)
+		for i := 0; i < o.Nx; i++ {
+			o.Rx[i] = Px[i] + o.Q[i]
+		}
+		la.SpMatTrVecMulAdd(o.Rx, 1, o.A, o.Ye)
+		la.SpMatTrVecMulAdd(o.Rx, 1, o.G, o.Z)
+		la.SpMatVecMul(o.Rye, 1, o.A, o.X)
+		for i := 0; i < o.Nl; i++ {
+			o.Rye[i] -= o.B[i]
+		}
+		la.SpMatVecMul(o.Rz, 1, o.G, o.X)
+		μ = 0
+		for i := 0; i < o.Ns; i++ {
+			o.Rz[i] += o.S[i] - o.H[i]
+			μ += o.S[i] * o.Z[i]
+		}
+		if o.Ns > 0 {
+			μ /= float64(o.Ns)
+		}
+
+		// check convergence: gap between f(x) and the dual objective -½xᵀPx - bᵀye - hᵀz
+		ctx = la.VecDot(o.Q, o.X) + 0.5*la.VecDot(o.X, Px)
+		btl = -0.5*la.VecDot(o.X, Px) - la.VecDot(o.B, o.Ye) - la.VecDot(o.H, o.Z)
+		lerr := math.Abs(ctx-btl) / (1.0 + math.Abs(ctx))
+		if verbose {
+			io.Pf("%3d%16.8e%16.8e\n", it, ctx, lerr)
+		}
+		if lerr < o.Tol && μ < o.Tol {
+			break
+		}
+
+		// assemble reduced KKT matrix (Δs is eliminated via the primal row GΔx+Δs=-rz and
+		// the complementarity row SΔz+ZΔs=-rs; see the RHS construction below for the
+		// resulting third-row right-hand side, which differs between the affine and the
+		// corrected step)
+		//  [ P   Aᵀ   Gᵀ ] [Δx ]     [rx ]
+		//  [ A    0    0 ] [Δye] = -[rye]
+		//  [ G    0  -W² ] [Δz ]     [rz ]
+		iy := o.Nx
+		iz := o.Nx + o.Nl
+		o.J.Start()
+		putCCMatAt(o.J, o.P, 0, 0, 1)
+		putCCMatAt(o.J, o.A, iy, 0, 1)
+		putCCMatTrAt(o.J, o.A, 0, iy, 1)
+		putCCMatAt(o.J, o.G, iz, 0, 1)
+		putCCMatTrAt(o.J, o.G, 0, iz, 1)
+		for i := 0; i < o.Ns; i++ {
+			o.J.Put(iz+i, iz+i, -o.S[i]/o.Z[i])
+		}
+
+		// factorise
+		if it == 0 {
+			err = o.Lis.InitR(o.J, symmetric, false, timing)
+			if err != nil {
+				return
+			}
+		}
+		err = o.Lis.Fact()
+		if err != nil {
+			return
+		}
+
+		// rzOrig keeps the raw primal residual rz=Gx+s-h: the z-block of o.R is overwritten
+		// below with the reduced-system RHS (which differs from rz by -s and, in the
+		// corrector, by the centering/cross-term), but Mds is always recovered from the
+		// primal feasibility relation Δs = -rz - GΔx, i.e. Mds = rz - G*Mdx
+		copy(rzOrig, o.Rz)
+
+		// affine step (σ=0): reduced system derived by eliminating Δs via the primal row
+		// GΔx+Δs=-rz and the complementarity row SΔz+ZΔs=-(Sz), giving GΔx-W²Δz = rz-s
+		for i := 0; i < o.Ns; i++ {
+			o.Rz[i] = rzOrig[i] - o.S[i]
+		}
+		err = o.Lis.SolveR(o.Mdy, o.R, false)
+		if err != nil {
+			return
+		}
+		la.SpMatVecMul(Gdx, 1, o.G, o.Mdx)
+		for i := 0; i < o.Ns; i++ {
+			o.Mds[i] = rzOrig[i] - Gdx[i]
+		}
+		αp, αd = o.calc_max_steps()
+
+		// corrector: μaff and centering parameter σ
+		μaff = 0
+		for i := 0; i < o.Ns; i++ {
+			μaff += (o.S[i] - αp*o.Mds[i]) * (o.Z[i] - αd*o.Mdz[i])
+		}
+		if o.Ns > 0 {
+			μaff /= float64(o.Ns)
+		}
+		if μ > 0 {
+			σ = math.Pow(μaff/μ, 3)
+		}
+
+		// corrected RHS: GΔx-W²Δz = rz-s - (ΔsΔz-σμ)/z
+		for i := 0; i < o.Ns; i++ {
+			o.Rz[i] = rzOrig[i] - o.S[i] - (o.Mds[i]*o.Mdz[i]-σ*μ)/o.Z[i]
+		}
+
+		// solve again for the corrected step
+		err = o.Lis.SolveR(o.Mdy, o.R, false)
+		if err != nil {
+			return
+		}
+		la.SpMatVecMul(Gdx, 1, o.G, o.Mdx)
+		for i := 0; i < o.Ns; i++ {
+			o.Mds[i] = rzOrig[i] - Gdx[i]
+		}
+
+		// fraction-to-boundary step lengths
+		αp, αd = o.calc_max_steps()
+		αp = min(1, 0.99*αp)
+		αd = min(1, 0.99*αd)
+
+		// update
+		for i := 0; i < o.Nx; i++ {
+			o.X[i] -= αp * o.Mdx[i]
+		}
+		for i := 0; i < o.Nl; i++ {
+			o.Ye[i] -= αd * o.Mdye[i]
+		}
+		for i := 0; i < o.Ns; i++ {
+			o.S[i] -= αp * o.Mds[i]
+			o.Z[i] -= αd * o.Mdz[i]
+		}
+	}
+
+	// check convergence
+	if it == o.NmaxIt {
+		err = chk.Err("iterations did not converge")
+	}
+	return
+}
+
+// calc_max_steps computes the largest step lengths (capped at 1) that keep s and z strictly
+// positive, i.e. min{ s_i / Mds_i : Mds_i > 0 } and min{ z_i / Mdz_i : Mdz_i > 0 }
+func (o *QuadIpm) calc_max_steps() (αp, αd float64) {
+	αp, αd = 1, 1
+	for i := 0; i < o.Ns; i++ {
+		if o.Mds[i] > 0 {
+			αp = min(αp, o.S[i]/o.Mds[i])
+		}
+		if o.Mdz[i] > 0 {
+			αd = min(αd, o.Z[i]/o.Mdz[i])
+		}
+	}
+	return
+}
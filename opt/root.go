@@ -0,0 +1,369 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// RootFunc is the residual function F: Rⁿ → Rⁿ of a nonlinear system F(x)=0. The function
+// must fill fx with F(x) given x
+type RootFunc func(fx, x []float64)
+
+// RootJacFunc is the analytic Jacobian ∂F/∂x of a RootFunc, filled into J given x
+type RootJacFunc func(J *la.Matrix, x []float64)
+
+// RootMethod selects the nonlinear-system solution strategy used by Root.Solve
+type RootMethod int
+
+const (
+	// RootNewton is Newton's method with Armijo backtracking line search on ½‖F‖²
+	RootNewton RootMethod = iota
+
+	// RootDogleg is Powell's hybrid dogleg method with a trust-region radius
+	RootDogleg
+)
+
+// RootTrace records the iteration history of Root.Solve so the convergence behaviour can be
+// plotted: the residual norm ‖F‖, the step norm ‖Δx‖ and, for RootDogleg, the trust-region
+// radius Δ at every iteration
+type RootTrace struct {
+	FNorm    []float64 // ‖F(x)‖ at each iteration
+	StepNorm []float64 // ‖Δx‖ at each iteration
+	Delta    []float64 // trust-region radius Δ at each iteration (RootDogleg only)
+}
+
+// Root solves a nonlinear system F(x)=0 with either a line-search Newton method or Powell's
+// hybrid dogleg method
+type Root struct {
+
+	// problem
+	Nx  int
+	F   RootFunc
+	Jac RootJacFunc
+
+	// constants
+	Method   RootMethod
+	NmaxIt   int     // max number of iterations
+	Tol      float64 // tolerance on ‖F‖
+	FDeps    float64 // forward-difference step size, used when Jac is nil
+	DeltaI   float64 // initial trust-region radius (RootDogleg)
+	DeltaMax float64 // maximum trust-region radius (RootDogleg)
+
+	// results
+	X     []float64 // solution
+	Trace RootTrace // iteration history
+}
+
+// Init initialises Root
+func (o *Root) Init(nx int, f RootFunc, jac RootJacFunc, prms fun.Params) {
+	o.Nx = nx
+	o.F = f
+	o.Jac = jac
+	o.Method = RootNewton
+	o.NmaxIt = 200
+	o.Tol = 1e-9
+	o.FDeps = 1e-7
+	o.DeltaI = 1
+	o.DeltaMax = 1e3
+	for _, p := range prms {
+		switch p.N {
+		case "method":
+			o.Method = RootMethod(int(p.V))
+		case "nmaxit":
+			o.NmaxIt = int(p.V)
+		case "fdeps":
+			o.FDeps = p.V
+		case "deltai":
+			o.DeltaI = p.V
+		case "deltamax":
+			o.DeltaMax = p.V
+		}
+	}
+}
+
+// jacobian fills J with the analytic Jacobian if available, otherwise with a forward-difference
+// approximation: J[:,j] ≈ (F(x+h eⱼ) - F(x)) / h
+func (o *Root) jacobian(J *la.Matrix, x, fx []float64) {
+	if o.Jac != nil {
+		o.Jac(J, x)
+		return
+	}
+	xh := make([]float64, o.Nx)
+	fh := make([]float64, o.Nx)
+	copy(xh, x)
+	for j := 0; j < o.Nx; j++ {
+		h := o.FDeps * math.Max(1, math.Abs(x[j]))
+		xh[j] = x[j] + h
+		o.F(fh, xh)
+		xh[j] = x[j]
+		for i := 0; i < o.Nx; i++ {
+			J.Set(i, j, (fh[i]-fx[i])/h)
+		}
+	}
+}
+
+// Solve solves the nonlinear system starting from x0, storing the result in o.X
+func (o *Root) Solve(x0 []float64) (err error) {
+	o.X = make([]float64, o.Nx)
+	copy(o.X, x0)
+	o.Trace = RootTrace{}
+
+	fx := make([]float64, o.Nx)
+	J := la.NewMatrix(o.Nx, o.Nx)
+
+	switch o.Method {
+	case RootDogleg:
+		err = o.solveDogleg(fx, J)
+	default:
+		err = o.solveNewton(fx, J)
+	}
+	return
+}
+
+// solveNewton implements Newton's method with Armijo backtracking on ½‖F‖²
+func (o *Root) solveNewton(fx []float64, J *la.Matrix) (err error) {
+	d := make([]float64, o.Nx)
+	xnew := make([]float64, o.Nx)
+	fnew := make([]float64, o.Nx)
+	const c1 = 1e-4
+	for it := 0; it < o.NmaxIt; it++ {
+		o.F(fx, o.X)
+		fnorm := la.VecDot(fx, fx)
+		o.Trace.FNorm = append(o.Trace.FNorm, math.Sqrt(fnorm))
+		if math.Sqrt(fnorm) < o.Tol {
+			return nil
+		}
+		o.jacobian(J, o.X, fx)
+		neg := make([]float64, o.Nx)
+		for i := 0; i < o.Nx; i++ {
+			neg[i] = -fx[i]
+		}
+		d, err = denseSolve(J, neg)
+		if err != nil {
+			return err
+		}
+
+		// Armijo backtracking line search on merit(x) = ½‖F(x)‖²
+		merit0 := 0.5 * fnorm
+		t := 1.0
+		for ls := 0; ls < 30; ls++ {
+			for i := 0; i < o.Nx; i++ {
+				xnew[i] = o.X[i] + t*d[i]
+			}
+			o.F(fnew, xnew)
+			meritNew := 0.5 * la.VecDot(fnew, fnew)
+			if meritNew <= (1-2*c1*t)*merit0 {
+				break
+			}
+			t *= 0.5
+		}
+		copy(o.X, xnew)
+		o.Trace.StepNorm = append(o.Trace.StepNorm, t*math.Sqrt(la.VecDot(d, d)))
+	}
+	return chk.Err("Root.Solve (Newton): iterations did not converge")
+}
+
+// solveDogleg implements Powell's hybrid dogleg trust-region method
+func (o *Root) solveDogleg(fx []float64, J *la.Matrix) (err error) {
+	Δ := o.DeltaI
+	o.F(fx, o.X)
+	fnorm2 := la.VecDot(fx, fx)
+	p := make([]float64, o.Nx)
+	xnew := make([]float64, o.Nx)
+	fnew := make([]float64, o.Nx)
+	Jp := make([]float64, o.Nx)
+	for it := 0; it < o.NmaxIt; it++ {
+		o.Trace.FNorm = append(o.Trace.FNorm, math.Sqrt(fnorm2))
+		o.Trace.Delta = append(o.Trace.Delta, Δ)
+		if math.Sqrt(fnorm2) < o.Tol {
+			return nil
+		}
+		o.jacobian(J, o.X, fx)
+
+		// Newton (Gauss-Newton) step: J pN = -F
+		neg := make([]float64, o.Nx)
+		for i := 0; i < o.Nx; i++ {
+			neg[i] = -fx[i]
+		}
+		pN, errN := denseSolve(J, neg)
+
+		// Cauchy (steepest-descent) step along g = Jᵀ F
+		g := make([]float64, o.Nx)
+		matTrVecDense(g, J, fx)
+		Jg := make([]float64, o.Nx)
+		matVecDense(Jg, J, g)
+		gg := la.VecDot(g, g)
+		jgjg := la.VecDot(Jg, Jg)
+		var τc float64
+		if jgjg > 0 {
+			τc = gg / jgjg
+		}
+		pC := make([]float64, o.Nx)
+		for i := 0; i < o.Nx; i++ {
+			pC[i] = -τc * g[i]
+		}
+		normPC := math.Sqrt(la.VecDot(pC, pC))
+
+		// dogleg combination of pC and pN within the trust region of radius Δ
+		if errN == nil && math.Sqrt(la.VecDot(pN, pN)) <= Δ {
+			copy(p, pN)
+		} else if normPC >= Δ {
+			for i := 0; i < o.Nx; i++ {
+				p[i] = (Δ / normPC) * pC[i]
+			}
+		} else if errN == nil {
+			// find τ ∈ [0,1] such that ‖pC + τ(pN-pC)‖ = Δ
+			diff := make([]float64, o.Nx)
+			for i := 0; i < o.Nx; i++ {
+				diff[i] = pN[i] - pC[i]
+			}
+			a := la.VecDot(diff, diff)
+			b := 2 * la.VecDot(pC, diff)
+			c := normPC*normPC - Δ*Δ
+			τ := 1.0
+			if a > 0 {
+				τ = (-b + math.Sqrt(math.Max(b*b-4*a*c, 0))) / (2 * a)
+			}
+			for i := 0; i < o.Nx; i++ {
+				p[i] = pC[i] + τ*diff[i]
+			}
+		} else {
+			for i := 0; i < o.Nx; i++ {
+				p[i] = pC[i]
+			}
+		}
+		stepNorm := math.Sqrt(la.VecDot(p, p))
+		o.Trace.StepNorm = append(o.Trace.StepNorm, stepNorm)
+
+		// actual vs. predicted reduction ratio ρ
+		for i := 0; i < o.Nx; i++ {
+			xnew[i] = o.X[i] + p[i]
+		}
+		o.F(fnew, xnew)
+		fnewnorm2 := la.VecDot(fnew, fnew)
+		matVecDense(Jp, J, p)
+		var predicted float64
+		for i := 0; i < o.Nx; i++ {
+			mp := fx[i] + Jp[i]
+			predicted += mp * mp
+		}
+		ared := fnorm2 - fnewnorm2
+		pred := fnorm2 - predicted
+		ρ := 1.0
+		if pred != 0 {
+			ρ = ared / pred
+		}
+
+		// trust-region radius update
+		if ρ < 0.25 {
+			Δ = 0.25 * Δ
+		} else if ρ > 0.75 && stepNorm >= 0.99*Δ {
+			Δ = math.Min(2*Δ, o.DeltaMax)
+		}
+
+		// accept or reject the step
+		if ρ > 0 {
+			copy(o.X, xnew)
+			copy(fx, fnew)
+			fnorm2 = fnewnorm2
+		}
+	}
+	return chk.Err("Root.Solve (dogleg): iterations did not converge")
+}
+
+// matVecDense computes v := M*u for a dense la.Matrix
+func matVecDense(v []float64, M *la.Matrix, u []float64) {
+	n := M.M
+	m := M.N
+	for i := 0; i < n; i++ {
+		var s float64
+		for j := 0; j < m; j++ {
+			s += M.Get(i, j) * u[j]
+		}
+		v[i] = s
+	}
+}
+
+// matTrVecDense computes v := Mᵀ*u for a dense la.Matrix
+func matTrVecDense(v []float64, M *la.Matrix, u []float64) {
+	n := M.M
+	m := M.N
+	for j := 0; j < m; j++ {
+		v[j] = 0
+	}
+	for i := 0; i < n; i++ {
+		ui := u[i]
+		for j := 0; j < m; j++ {
+			v[j] += M.Get(i, j) * ui
+		}
+	}
+}
+
+// denseSolve solves the n×n dense system M*x=b by Gaussian elimination with partial pivoting
+func denseSolve(M *la.Matrix, b []float64) (x []float64, err error) {
+	n := M.M
+	A := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		A[i] = make([]float64, n+1)
+		for j := 0; j < n; j++ {
+			A[i][j] = M.Get(i, j)
+		}
+		A[i][n] = b[i]
+	}
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(A[i][k]) > math.Abs(A[p][k]) {
+				p = i
+			}
+		}
+		if math.Abs(A[p][k]) < 1e-300 {
+			return nil, chk.Err("denseSolve: singular matrix")
+		}
+		A[k], A[p] = A[p], A[k]
+		for i := k + 1; i < n; i++ {
+			f := A[i][k] / A[k][k]
+			for j := k; j <= n; j++ {
+				A[i][j] -= f * A[k][j]
+			}
+		}
+	}
+	x = make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		s := A[i][n]
+		for j := i + 1; j < n; j++ {
+			s -= A[i][j] * x[j]
+		}
+		x[i] = s / A[i][i]
+	}
+	return x, nil
+}
+
+// RootFD solves F(x)=0 using a forward-difference approximation of the Jacobian, starting
+// from x0 and iterating at most maxit times or until ‖F‖<tol
+func RootFD(f RootFunc, x0 []float64, tol float64, maxit int) (x []float64, trace RootTrace, err error) {
+	o := new(Root)
+	o.Init(len(x0), f, nil, nil)
+	o.Tol = tol
+	o.NmaxIt = maxit
+	err = o.Solve(x0)
+	return o.X, o.Trace, err
+}
+
+// RootJ solves F(x)=0 using the analytic Jacobian jac, starting from x0 and iterating at most
+// maxit times or until ‖F‖<tol
+func RootJ(f RootFunc, jac RootJacFunc, x0 []float64, tol float64, maxit int) (x []float64, trace RootTrace, err error) {
+	o := new(Root)
+	o.Init(len(x0), f, jac, nil)
+	o.Tol = tol
+	o.NmaxIt = maxit
+	err = o.Solve(x0)
+	return o.X, o.Trace, err
+}
@@ -0,0 +1,156 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import "math"
+
+// matFromVec reshapes a length-n*n slice (row-major, unpacked PSD-block storage) into an n×n
+// dense matrix
+func matFromVec(v []float64, n int) (m [][]float64) {
+	m = make([][]float64, n)
+	for i := 0; i < n; i++ {
+		m[i] = make([]float64, n)
+		copy(m[i], v[i*n:(i+1)*n])
+	}
+	return
+}
+
+// matToVec flattens an n×n dense matrix back into row-major, unpacked storage
+func matToVec(m [][]float64, v []float64) {
+	n := len(m)
+	for i := 0; i < n; i++ {
+		copy(v[i*n:(i+1)*n], m[i])
+	}
+}
+
+// matMulSym3 returns the product a*b*c of n×n dense matrices (called with c = a to form a*b*a)
+func matMulSym3(a, b [][]float64, c [][]float64) (r [][]float64) {
+	tmp := matMulDense(a, b)
+	r = matMulDense(tmp, c)
+	return
+}
+
+// matMulDense returns the n×n product a*b
+func matMulDense(a, b [][]float64) (r [][]float64) {
+	n := len(a)
+	r = make([][]float64, n)
+	for i := 0; i < n; i++ {
+		r[i] = make([]float64, n)
+		for j := 0; j < n; j++ {
+			var s float64
+			for k := 0; k < n; k++ {
+				s += a[i][k] * b[k][j]
+			}
+			r[i][j] = s
+		}
+	}
+	return
+}
+
+// matSqrtSym returns the symmetric positive (semi-)definite square root of the symmetric
+// matrix a, computed from its eigendecomposition a = VΛVᵀ as V√ΛVᵀ
+func matSqrtSym(a [][]float64) (r [][]float64) {
+	n := len(a)
+	_, λ, v := jacobiEigenSym(a)
+	sq := make([]float64, n)
+	for i := 0; i < n; i++ {
+		sq[i] = math.Sqrt(math.Max(λ[i], 0))
+	}
+	return rebuildSym(v, sq)
+}
+
+// matInvSqrtSym returns the inverse of the symmetric positive definite square root of a
+func matInvSqrtSym(a [][]float64) (r [][]float64) {
+	n := len(a)
+	_, λ, v := jacobiEigenSym(a)
+	isq := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if λ[i] > 0 {
+			isq[i] = 1 / math.Sqrt(λ[i])
+		}
+	}
+	return rebuildSym(v, isq)
+}
+
+// rebuildSym reconstructs V*diag(d)*Vᵀ from the eigenvectors v (columns) and eigenvalue
+// function values d
+func rebuildSym(v [][]float64, d []float64) (r [][]float64) {
+	n := len(v)
+	r = make([][]float64, n)
+	for i := 0; i < n; i++ {
+		r[i] = make([]float64, n)
+	}
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				r[i][j] += d[k] * v[i][k] * v[j][k]
+			}
+		}
+	}
+	return
+}
+
+// jacobiEigenSym computes the eigenvalues λ and eigenvectors v (as columns of the returned
+// matrix) of the symmetric n×n matrix a using the classical cyclic Jacobi rotation method.
+// ok is false if the method failed to converge within the iteration budget
+func jacobiEigenSym(a [][]float64) (ok bool, λ []float64, v [][]float64) {
+	n := len(a)
+	A := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		A[i] = make([]float64, n)
+		copy(A[i], a[i])
+	}
+	v = make([][]float64, n)
+	for i := 0; i < n; i++ {
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+	const maxSweeps = 100
+	for sweep := 0; sweep < maxSweeps; sweep++ {
+		var off float64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += A[i][j] * A[i][j]
+			}
+		}
+		if off < 1e-30 {
+			ok = true
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(A[p][q]) < 1e-300 {
+					continue
+				}
+				θ := (A[q][q] - A[p][p]) / (2 * A[p][q])
+				t := math.Copysign(1, θ) / (math.Abs(θ) + math.Sqrt(1+θ*θ))
+				c := 1 / math.Sqrt(1+t*t)
+				s := t * c
+				app, aqq, apq := A[p][p], A[q][q], A[p][q]
+				A[p][p] = c*c*app - 2*s*c*apq + s*s*aqq
+				A[q][q] = s*s*app + 2*s*c*apq + c*c*aqq
+				A[p][q] = 0
+				A[q][p] = 0
+				for i := 0; i < n; i++ {
+					if i != p && i != q {
+						aip, aiq := A[i][p], A[i][q]
+						A[i][p] = c*aip - s*aiq
+						A[p][i] = A[i][p]
+						A[i][q] = s*aip + c*aiq
+						A[q][i] = A[i][q]
+					}
+					vip, viq := v[i][p], v[i][q]
+					v[i][p] = c*vip - s*viq
+					v[i][q] = s*vip + c*viq
+				}
+			}
+		}
+	}
+	λ = make([]float64, n)
+	for i := 0; i < n; i++ {
+		λ[i] = A[i][i]
+	}
+	return
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// TestConeIpm01 solves, with an orthant-only cone (L=2, no SOC/PSD blocks), the LP
+// min 2x1+x2 s.t. x1+x2=1, x≥0, whose unique optimum is x=(0,1)
+func TestConeIpm01(t *testing.T) {
+
+	var Atri la.Triplet
+	Atri.Init(1, 2, 2)
+	Atri.Put(0, 0, 1)
+	Atri.Put(0, 1, 1)
+	A := Atri.ToMatrix()
+	b := []float64{1}
+
+	var Gtri la.Triplet
+	Gtri.Init(2, 2, 2)
+	Gtri.Put(0, 0, -1)
+	Gtri.Put(1, 1, -1)
+	G := Gtri.ToMatrix()
+	h := []float64{0, 0}
+
+	c := []float64{2, 1}
+	dims := ConeDims{L: 2}
+
+	var o ConeIpm
+	o.Init(dims, A, b, G, h, c, nil)
+	defer o.Free()
+	err := o.Solve(false)
+	if err != nil {
+		t.Fatalf("ConeIpm.Solve failed: %v", err)
+	}
+	if math.Abs(o.X[0]-0) > 1e-4 || math.Abs(o.X[1]-1) > 1e-4 {
+		t.Fatalf("wrong solution: x=%v (want [0 1])", o.X)
+	}
+}
+
+// TestConeIpm02 solves, with a single second-order cone (Q=[3]), x=(t,x1,x2) minimizing t
+// subject to x1=1, x2=0 and (t,x1,x2)∈Q (t≥√(x1²+x2²)), whose unique optimum is t=1
+func TestConeIpm02(t *testing.T) {
+
+	var Atri la.Triplet
+	Atri.Init(2, 3, 2)
+	Atri.Put(0, 1, 1)
+	Atri.Put(1, 2, 1)
+	A := Atri.ToMatrix()
+	b := []float64{1, 0}
+
+	var Gtri la.Triplet
+	Gtri.Init(3, 3, 3)
+	Gtri.Put(0, 0, -1)
+	Gtri.Put(1, 1, -1)
+	Gtri.Put(2, 2, -1)
+	G := Gtri.ToMatrix()
+	h := []float64{0, 0, 0}
+
+	c := []float64{1, 0, 0}
+	dims := ConeDims{Q: []int{3}}
+
+	var o ConeIpm
+	o.Init(dims, A, b, G, h, c, nil)
+	defer o.Free()
+	err := o.Solve(false)
+	if err != nil {
+		t.Fatalf("ConeIpm.Solve failed: %v", err)
+	}
+	if math.Abs(o.X[0]-1) > 1e-4 || math.Abs(o.X[1]-1) > 1e-4 || math.Abs(o.X[2]-0) > 1e-4 {
+		t.Fatalf("wrong solution: x=%v (want [1 1 0])", o.X)
+	}
+}
+
+// TestConeIpm03 solves, with a single PSD cone (S=[2], x storing the unpacked 2×2 matrix in
+// row-major order), max x1 (i.e. min -x1) s.t. x0=1, x3=1 (diagonal fixed), x1=x2 (symmetry),
+// X=[[x0,x1],[x2,x3]]⪰0 — whose unique optimum is the rank-1 boundary point X=[[1,1],[1,1]]
+func TestConeIpm03(t *testing.T) {
+
+	var Atri la.Triplet
+	Atri.Init(3, 4, 4)
+	Atri.Put(0, 0, 1)
+	Atri.Put(1, 3, 1)
+	Atri.Put(2, 1, 1)
+	Atri.Put(2, 2, -1)
+	A := Atri.ToMatrix()
+	b := []float64{1, 1, 0}
+
+	var Gtri la.Triplet
+	Gtri.Init(4, 4, 4)
+	for i := 0; i < 4; i++ {
+		Gtri.Put(i, i, -1)
+	}
+	G := Gtri.ToMatrix()
+	h := []float64{0, 0, 0, 0}
+
+	c := []float64{0, -1, 0, 0}
+	dims := ConeDims{S: []int{2}}
+
+	var o ConeIpm
+	o.Init(dims, A, b, G, h, c, fun.Params{{N: "nmaxit", V: 100}})
+	defer o.Free()
+	err := o.Solve(false)
+	if err != nil {
+		t.Fatalf("ConeIpm.Solve failed: %v", err)
+	}
+	if math.Abs(o.X[0]-1) > 1e-3 || math.Abs(o.X[1]-1) > 1e-3 ||
+		math.Abs(o.X[2]-1) > 1e-3 || math.Abs(o.X[3]-1) > 1e-3 {
+		t.Fatalf("wrong solution: x=%v (want [1 1 1 1])", o.X)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// TestKKTSolver01 solves the same tiny LP (min 2x1+x2 s.t. x1+x2=1, x≥0, optimum x=(0,1)) with
+// each KKTSolver backend, checking that the iterative backends match the direct UMFPACK result
+func TestKKTSolver01(t *testing.T) {
+
+	var Atri la.Triplet
+	Atri.Init(1, 2, 2)
+	Atri.Put(0, 0, 1)
+	Atri.Put(0, 1, 1)
+	A := Atri.ToMatrix()
+	b := []float64{1}
+	c := []float64{2, 1}
+
+	backends := []fun.Params{
+		nil,                      // default: direct UMFPACK
+		{{N: "kktsolver", V: 1}}, // bicgstab
+		{{N: "kktsolver", V: 2}}, // gmres
+	}
+	for _, prms := range backends {
+		var o LinIpm
+		o.Init(A, b, c, prms)
+		err := o.Solve(false)
+		o.Free()
+		if err != nil {
+			t.Fatalf("LinIpm.Solve failed with prms=%v: %v", prms, err)
+		}
+		if math.Abs(o.X[0]-0) > 1e-3 || math.Abs(o.X[1]-1) > 1e-3 {
+			t.Fatalf("wrong solution with prms=%v: x=%v (want [0 1])", prms, o.X)
+		}
+	}
+}
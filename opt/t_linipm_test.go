@@ -0,0 +1,99 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/la"
+)
+
+// linipmTriplet01 builds the 1x2 LP min x1+2x2 s.t. x1+x2=b, x≥0, whose optimum (for b>0) is
+// the non-degenerate vertex x=(b,0), λ=1, as long as the basis (x1 basic, x2 nonbasic) holds
+func linipmTriplet01(b float64) (A *la.CCMatrix, bb, c []float64) {
+	var Atri la.Triplet
+	Atri.Init(1, 2, 2)
+	Atri.Put(0, 0, 1)
+	Atri.Put(0, 1, 1)
+	return Atri.ToMatrix(), []float64{b}, []float64{1, 2}
+}
+
+// TestLinIpmSensitivity01 checks SensitivityRHS and SensitivityCost, at the optimum of
+// min x1+2x2 s.t. x1+x2=1, x≥0 (optimum x=(1,0), λ=1), against finite differences
+func TestLinIpmSensitivity01(t *testing.T) {
+
+	A, b, c := linipmTriplet01(1)
+	var o LinIpm
+	o.Init(A, b, c, nil)
+	defer o.Free()
+	if err := o.Solve(false); err != nil {
+		t.Fatalf("LinIpm.Solve failed: %v", err)
+	}
+	if math.Abs(o.X[0]-1) > 1e-6 || math.Abs(o.X[1]-0) > 1e-6 || math.Abs(o.L[0]-1) > 1e-6 {
+		t.Fatalf("wrong base solution: x=%v l=%v (want x=[1 0] l=[1])", o.X, o.L)
+	}
+
+	// analytic sensitivities
+	dx, dλ := o.SensitivityRHS([]float64{1})
+	if math.Abs(dx[0]-1) > 1e-6 || math.Abs(dx[1]-0) > 1e-6 || math.Abs(dλ[0]-0) > 1e-6 {
+		t.Fatalf("wrong SensitivityRHS: dx=%v dl=%v (want dx=[1 0] dl=[0])", dx, dλ)
+	}
+	dx2, dλ2 := o.SensitivityCost([]float64{1, 0})
+	if math.Abs(dx2[0]-0) > 1e-6 || math.Abs(dx2[1]-0) > 1e-6 || math.Abs(dλ2[0]-1) > 1e-6 {
+		t.Fatalf("wrong SensitivityCost: dx=%v dl=%v (want dx=[0 0] dl=[1])", dx2, dλ2)
+	}
+
+	// cross-check SensitivityRHS against a finite difference on b
+	const eps = 1e-6
+	Ap, bp, cp := linipmTriplet01(1 + eps)
+	var op LinIpm
+	op.Init(Ap, bp, cp, nil)
+	defer op.Free()
+	if err := op.Solve(false); err != nil {
+		t.Fatalf("LinIpm.Solve (perturbed b) failed: %v", err)
+	}
+	dxFD := (op.X[0] - o.X[0]) / eps
+	if math.Abs(dxFD-dx[0]) > 1e-3 {
+		t.Fatalf("SensitivityRHS disagrees with finite difference: analytic=%v fd=%v", dx[0], dxFD)
+	}
+
+	// cross-check SensitivityCost against a finite difference on c1
+	var oc LinIpm
+	oc.Init(A, b, []float64{1 + eps, 2}, nil)
+	defer oc.Free()
+	if err := oc.Solve(false); err != nil {
+		t.Fatalf("LinIpm.Solve (perturbed c) failed: %v", err)
+	}
+	dλFD := (oc.L[0] - o.L[0]) / eps
+	if math.Abs(dλFD-dλ2[0]) > 1e-3 {
+		t.Fatalf("SensitivityCost disagrees with finite difference: analytic=%v fd=%v", dλ2[0], dλFD)
+	}
+}
+
+// TestLinIpmWarmStart01 checks that WarmStart followed by Solve converges to the optimum of a
+// perturbed LP, starting from the optimum of the original problem
+func TestLinIpmWarmStart01(t *testing.T) {
+
+	A, b, c := linipmTriplet01(1)
+	var o LinIpm
+	o.Init(A, b, c, nil)
+	defer o.Free()
+	if err := o.Solve(false); err != nil {
+		t.Fatalf("LinIpm.Solve failed: %v", err)
+	}
+
+	Ap, bp, cp := linipmTriplet01(2)
+	var op LinIpm
+	op.Init(Ap, bp, cp, nil)
+	defer op.Free()
+	op.WarmStart(o.X, o.L, o.S)
+	if err := op.Solve(false); err != nil {
+		t.Fatalf("LinIpm.Solve (warm-started) failed: %v", err)
+	}
+	if math.Abs(op.X[0]-2) > 1e-4 || math.Abs(op.X[1]-0) > 1e-4 {
+		t.Fatalf("wrong warm-started solution: x=%v (want [2 0])", op.X)
+	}
+}
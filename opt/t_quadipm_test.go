@@ -0,0 +1,48 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/la"
+)
+
+// TestQuadIpm01 solves min ½(x1²+x2²) s.t. x1+x2=1, x1+x2≥1 (a trivial equality thrown in to
+// exercise the Nl>0 path) whose unique optimum is x=(0.5,0.5)
+func TestQuadIpm01(t *testing.T) {
+
+	var Ptri la.Triplet
+	Ptri.Init(2, 2, 2)
+	Ptri.Put(0, 0, 1)
+	Ptri.Put(1, 1, 1)
+	q := []float64{0, 0}
+
+	var Gtri la.Triplet
+	Gtri.Init(1, 2, 2)
+	Gtri.Put(0, 0, -1)
+	Gtri.Put(0, 1, -1)
+	G := Gtri.ToMatrix()
+	h := []float64{-1}
+
+	var Atri la.Triplet
+	Atri.Init(1, 2, 2)
+	Atri.Put(0, 0, 1)
+	Atri.Put(0, 1, 1)
+	A := Atri.ToMatrix()
+	b := []float64{1}
+
+	var o QuadIpm
+	o.Init(&Ptri, q, G, h, A, b, nil)
+	defer o.Free()
+	err := o.Solve(false)
+	if err != nil {
+		t.Fatalf("QuadIpm.Solve failed: %v", err)
+	}
+	if math.Abs(o.X[0]-0.5) > 1e-4 || math.Abs(o.X[1]-0.5) > 1e-4 {
+		t.Fatalf("wrong solution: x=%v (want [0.5 0.5])", o.X)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2016 The Gosl Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package opt
+
+import (
+	"math"
+	"testing"
+)
+
+// rootF01 is F(x) = (x1²-1, x2-2), with the root closest to x0=(2,3) at x=(1,2)
+func rootF01(fx, x []float64) {
+	fx[0] = x[0]*x[0] - 1
+	fx[1] = x[1] - 2
+}
+
+func TestRootNewton01(t *testing.T) {
+	o := new(Root)
+	o.Init(2, rootF01, nil, nil)
+	o.Method = RootNewton
+	err := o.Solve([]float64{2, 3})
+	if err != nil {
+		t.Fatalf("Root.Solve (Newton) failed: %v", err)
+	}
+	if math.Abs(o.X[0]-1) > 1e-6 || math.Abs(o.X[1]-2) > 1e-6 {
+		t.Fatalf("wrong root: x=%v (want [1 2])", o.X)
+	}
+}
+
+func TestRootDogleg01(t *testing.T) {
+	o := new(Root)
+	o.Init(2, rootF01, nil, nil)
+	o.Method = RootDogleg
+	err := o.Solve([]float64{2, 3})
+	if err != nil {
+		t.Fatalf("Root.Solve (dogleg) failed: %v", err)
+	}
+	if math.Abs(o.X[0]-1) > 1e-6 || math.Abs(o.X[1]-2) > 1e-6 {
+		t.Fatalf("wrong root: x=%v (want [1 2])", o.X)
+	}
+}